@@ -0,0 +1,151 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"github.com/polarismesh/polaris-server/common/model"
+	"github.com/polarismesh/polaris-server/store"
+	"math/rand"
+	"time"
+)
+
+const (
+	BaseExpireTimeAfterWrite = 60 * 60 // expire after 1 hour
+	FileIdSeparator          = "+"
+
+	// DefaultMaxEntries 默认不限制缓存条目数量
+	DefaultMaxEntries = 0
+	// DefaultMaxByteSize 默认不限制缓存占用的内容字节数
+	DefaultMaxByteSize = 0
+
+	// BackendLocal 进程内缓存，默认后端
+	BackendLocal = "local"
+	// BackendRedis 使用 Redis 作为共享缓存后端，适用于多副本部署
+	BackendRedis = "redis"
+	// BackendMemcached 使用 Memcached 作为共享缓存后端，适用于多副本部署
+	BackendMemcached = "memcached"
+)
+
+// ConfigFileCache 配置文件缓存的统一接口，屏蔽具体缓存后端（进程内/Redis/Memcached）的差异，
+// 使多个 polaris-server 副本可以共享同一份缓存，避免各自独立预热、各自独立击穿存储层
+type ConfigFileCache interface {
+	// Put 写入缓存对象
+	Put(file *model.ConfigFileRelease)
+	// Get 获取缓存对象，只读取缓存，不会触发加载
+	Get(namespace, group, fileName string) (*Entry, bool)
+	// GetOrLoadIfAbsent 获取缓存，缓存未命中时从存储层加载并写回缓存
+	GetOrLoadIfAbsent(namespace, group, fileName string) (*Entry, error)
+	// Remove 删除缓存对象
+	Remove(namespace, group, fileName string)
+}
+
+// Entry 缓存实体对象。这是一个跨协程共享的不可变值：命中统计等易变状态不应该挂在它身上，
+// 否则持有旧 *Entry 的调用方会在无锁的情况下和后续的 Get 产生数据竞争，参见 lfuNode 上的
+// hitCount/lastAccessTime
+type Entry struct {
+	Content string
+	Md5     string
+	Version uint64
+	//创建的时候，设置过期时间
+	ExpireTime time.Time
+	//标识是否是空缓存
+	Empty bool
+}
+
+// Config 构建 ConfigFileCache 所需的配置
+type Config struct {
+	// Backend 缓存后端类型，取值为 BackendLocal、BackendRedis、BackendMemcached，默认为 BackendLocal
+	Backend string
+	// MaxEntries 仅 local 后端生效，LFU 淘汰的最大条目数，<= 0 表示不限制
+	MaxEntries int
+	// MaxByteSize 仅 local 后端生效，LFU 淘汰的最大内容字节数，<= 0 表示不限制
+	MaxByteSize int64
+	// Disk 仅 local 后端生效，Dir 非空时开启磁盘二级缓存
+	Disk DiskConfig
+	// Refresh 仅 local 后端生效，Workers > 0 时开启 refresh-after-write 后台异步刷新
+	Refresh RefreshConfig
+	// Redis 仅 Backend 为 redis 时生效
+	Redis RedisConfig
+	// Memcached 仅 Backend 为 memcached 时生效
+	Memcached MemcachedConfig
+}
+
+// NewConfigFileCache 根据 conf.Backend 构建对应的 ConfigFileCache 实现，storage 用于缓存未命中时加载数据
+func NewConfigFileCache(storage store.Store, conf *Config) (ConfigFileCache, error) {
+	if conf == nil {
+		conf = &Config{}
+	}
+
+	switch conf.Backend {
+	case "", BackendLocal:
+		return NewLocalFileCache(storage, conf.MaxEntries, conf.MaxByteSize, conf.Disk, conf.Refresh), nil
+	case BackendRedis:
+		return NewRedisFileCache(storage, conf.Redis)
+	case BackendMemcached:
+		return NewMemcachedFileCache(storage, conf.Memcached)
+	default:
+		return nil, fmt.Errorf("unknown config file cache backend: %s", conf.Backend)
+	}
+}
+
+func newEntry(content, md5 string, version uint64) *Entry {
+	return &Entry{
+		Content:    content,
+		Md5:        md5,
+		Version:    version,
+		ExpireTime: getExpireTime(),
+		Empty:      false,
+	}
+}
+
+// GenFileId 生成文件对象 Id
+func GenFileId(namespace, group, fileName string) string {
+	return namespace + FileIdSeparator + group + FileIdSeparator + fileName
+}
+
+//缓存过期时间，为了避免集中失效，加上随机数。[60 ~ 70]分钟内随机失效
+func getExpireTime() time.Time {
+	return time.Now().Add(getExpireDuration())
+}
+
+//缓存过期时长，为了避免集中失效，加上随机数。[60 ~ 70]分钟内随机失效
+func getExpireDuration() time.Duration {
+	randTime := rand.Intn(10*60) + BaseExpireTimeAfterWrite
+	return time.Duration(randTime) * time.Second
+}
+
+// encodeEntry 将 Entry 序列化为 gob 字节流，供 Redis/Memcached 等远程缓存后端存储
+func encodeEntry(e *Entry) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(e); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeEntry 将远程缓存后端读取的字节流反序列化为 Entry
+func decodeEntry(data []byte) (*Entry, error) {
+	entry := &Entry{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,268 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"github.com/polarismesh/polaris-server/common/log"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	diskFileSuffix       = ".cache"
+	diskCompactionPeriod = time.Minute
+)
+
+// DiskConfig 磁盘二级缓存的配置，Dir 为空表示不开启磁盘落盘
+type DiskConfig struct {
+	// Dir 持久化文件的存放目录，每个 fileId 对应一个文件
+	Dir string
+	// MaxDiskBytes 磁盘缓存允许占用的最大字节数，<= 0 表示不限制
+	MaxDiskBytes int64
+}
+
+// diskRecord 落盘的最小单元，额外保存 FileId 是因为磁盘上的文件名是 fileId 的哈希，
+// 冷启动扫描目录恢复数据时需要靠它还原出内存 map 的 key
+type diskRecord struct {
+	FileId string
+	Entry  *Entry
+}
+
+// diskTier FileCache 的持久化二级缓存，写穿到本地磁盘，重启时用于冷启动预热内存缓存
+type diskTier struct {
+	dir          string
+	maxDiskBytes int64
+
+	// writeLocks fileId -> *sync.Mutex，串行化同一个 fileId 的落盘写入，避免并发写同一个
+	// 临时文件路径时互相覆盖、或是较旧的写入在 rename 时覆盖较新的版本
+	writeLocks sync.Map
+}
+
+func newDiskTier(conf DiskConfig) (*diskTier, error) {
+	if err := os.MkdirAll(conf.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &diskTier{dir: conf.Dir, maxDiskBytes: conf.MaxDiskBytes}, nil
+}
+
+// diskFileName 用 fileId 的 sha256 作为文件名，规避 namespace/group/fileName 中可能出现的
+// 路径分隔符等非法字符
+func diskFileName(fileId string) string {
+	sum := sha256.Sum256([]byte(fileId))
+	return hex.EncodeToString(sum[:]) + diskFileSuffix
+}
+
+func (d *diskTier) path(fileId string) string {
+	return filepath.Join(d.dir, diskFileName(fileId))
+}
+
+// get 从磁盘读取一个条目，文件不存在、已过期或解析失败都视为未命中
+func (d *diskTier) get(fileId string) (*Entry, bool) {
+	record, ok := d.readRecord(d.path(fileId))
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(record.Entry.ExpireTime) {
+		_ = os.Remove(d.path(fileId))
+		return nil, false
+	}
+	return record.Entry, true
+}
+
+// put 将条目以 gob 编码写入磁盘，写入一个进程内唯一的临时文件再 rename 到目标路径，避免并发
+// 读到半截文件。同一个 fileId 的写入通过 writeLocks 串行化，并在写入前比较磁盘上已有记录的
+// Version，防止后写入但版本更旧的调用（例如 Put 与 chunk0-5 的后台刷新并发触发）覆盖掉更新的数据
+func (d *diskTier) put(fileId string, entry *Entry) {
+	lockObj, _ := d.writeLocks.LoadOrStore(fileId, new(sync.Mutex))
+	writeLock := lockObj.(*sync.Mutex)
+	writeLock.Lock()
+	defer writeLock.Unlock()
+
+	target := d.path(fileId)
+
+	if !entry.Empty {
+		if existing, ok := d.readRecord(target); ok && existing.Entry.Version > entry.Version {
+			return
+		}
+	}
+
+	record := &diskRecord{FileId: fileId, Entry: entry}
+
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(record); err != nil {
+		log.GetConfigLogger().Error("[Config][Cache] encode disk cache entry error.",
+			zap.String("fileId", fileId), zap.Error(err))
+		return
+	}
+
+	tmp, err := ioutil.TempFile(d.dir, filepath.Base(target)+".*.tmp")
+	if err != nil {
+		log.GetConfigLogger().Error("[Config][Cache] create disk cache tmp file error.",
+			zap.String("fileId", fileId), zap.Error(err))
+		return
+	}
+	tmpPath := tmp.Name()
+
+	_, writeErr := tmp.Write(buf.Bytes())
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil {
+		_ = os.Remove(tmpPath)
+		log.GetConfigLogger().Error("[Config][Cache] write disk cache entry error.",
+			zap.String("fileId", fileId), zap.Error(writeErr), zap.NamedError("closeErr", closeErr))
+		return
+	}
+
+	if err := os.Rename(tmpPath, target); err != nil {
+		_ = os.Remove(tmpPath)
+		log.GetConfigLogger().Error("[Config][Cache] rename disk cache entry error.",
+			zap.String("fileId", fileId), zap.Error(err))
+	}
+}
+
+func (d *diskTier) remove(fileId string) {
+	if err := os.Remove(d.path(fileId)); err != nil && !os.IsNotExist(err) {
+		log.GetConfigLogger().Error("[Config][Cache] remove disk cache entry error.",
+			zap.String("fileId", fileId), zap.Error(err))
+	}
+}
+
+// loadAll 扫描磁盘目录，返回所有未过期的记录；过期的记录顺带删除
+func (d *diskTier) loadAll() ([]*diskRecord, error) {
+	files, err := ioutil.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	records := make([]*diskRecord, 0, len(files))
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != diskFileSuffix {
+			continue
+		}
+
+		path := filepath.Join(d.dir, f.Name())
+		record, ok := d.readRecord(path)
+		if !ok {
+			continue
+		}
+		if now.After(record.Entry.ExpireTime) {
+			_ = os.Remove(path)
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func (d *diskTier) readRecord(path string) (*diskRecord, bool) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	record := &diskRecord{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(record); err != nil {
+		log.GetConfigLogger().Error("[Config][Cache] decode disk cache entry error.",
+			zap.String("path", path), zap.Error(err))
+		return nil, false
+	}
+	return record, true
+}
+
+// startCompactionTask 定期清理已过期的磁盘文件，并在超过 maxDiskBytes 时按过期时间从早到晚淘汰，
+// 直到磁盘占用回落到限制以内
+func (d *diskTier) startCompactionTask() {
+	t := time.NewTicker(diskCompactionPeriod)
+	go func() {
+		for range t.C {
+			d.compact()
+		}
+	}()
+}
+
+func (d *diskTier) compact() {
+	files, err := ioutil.ReadDir(d.dir)
+	if err != nil {
+		log.GetConfigLogger().Error("[Config][Cache] list disk cache dir error.", zap.Error(err))
+		return
+	}
+
+	type candidate struct {
+		path       string
+		size       int64
+		expireTime time.Time
+	}
+
+	now := time.Now()
+	var total int64
+	candidates := make([]candidate, 0, len(files))
+	removedExpired := 0
+
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != diskFileSuffix {
+			continue
+		}
+
+		path := filepath.Join(d.dir, f.Name())
+		record, ok := d.readRecord(path)
+		if !ok {
+			continue
+		}
+		if now.After(record.Entry.ExpireTime) {
+			_ = os.Remove(path)
+			removedExpired++
+			continue
+		}
+
+		total += f.Size()
+		candidates = append(candidates, candidate{path: path, size: f.Size(), expireTime: record.Entry.ExpireTime})
+	}
+
+	removedOverCap := 0
+	if d.maxDiskBytes > 0 && total > d.maxDiskBytes {
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].expireTime.Before(candidates[j].expireTime)
+		})
+		for _, c := range candidates {
+			if total <= d.maxDiskBytes {
+				break
+			}
+			if err := os.Remove(c.path); err != nil {
+				continue
+			}
+			total -= c.size
+			removedOverCap++
+		}
+	}
+
+	if removedExpired > 0 || removedOverCap > 0 {
+		log.GetConfigLogger().Info("[Config][Cache] disk cache compaction done.",
+			zap.Int("removedExpired", removedExpired),
+			zap.Int("removedOverCap", removedOverCap))
+	}
+}
@@ -0,0 +1,589 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"container/list"
+	"github.com/polarismesh/polaris-server/common/log"
+	"github.com/polarismesh/polaris-server/common/model"
+	"github.com/polarismesh/polaris-server/store"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LocalFileCache 进程内文件缓存，使用 loading cache 懒加载策略。同时使用写入一段时间后失效策略。
+// 当配置了 maxEntries 或 maxByteSize 时，淘汰策略采用 LFU：访问频次越低的 Entry 越先被淘汰，
+// 频次相同时按最近最少使用（LRU）淘汰，避免无限增长导致 polaris-server OOM。
+// 它是 ConfigFileCache 的默认实现，数据只保存在本进程内，多副本部署时各副本互不感知。
+type LocalFileCache struct {
+	storage store.Store
+
+	// maxEntries 允许缓存的最大条目数，<= 0 表示不限制
+	maxEntries int
+	// maxByteSize 允许缓存的内容总字节数的近似上限（按 len(Entry.Content) 统计），<= 0 表示不限制
+	maxByteSize int64
+
+	mu sync.Mutex
+	// fileId -> *lfuNode，与 freqHead 共同构成 LFU 频次链表
+	files map[string]*lfuNode
+	// 频次链表中最小频次的节点
+	freqHead *freqNode
+	// curByteSize 当前缓存内容占用的总字节数
+	curByteSize int64
+
+	// loadGroup 合并同一 fileId 的并发加载请求，避免像 fileLoadLocks 那样为每个访问过的
+	// fileId 永久保留一个 *sync.Mutex 造成内存泄漏
+	loadGroup singleflight.Group
+
+	// OnEvicted 条目被 LFU 淘汰时的回调，可用于通知长轮询等下游组件
+	OnEvicted func(fileId string, e *Entry)
+
+	// disk 持久化的第二级缓存，nil 表示未开启磁盘落盘
+	disk *diskTier
+
+	// refreshWindow 命中的 Entry 距离 ExpireTime 不足该时长时，触发一次后台异步刷新；<= 0 表示不开启
+	refreshWindow time.Duration
+	// refreshJobs 有界的后台刷新任务队列，配合固定数量的 worker 实现刷新并发度的上限
+	refreshJobs chan refreshJob
+	// refreshing 正在刷新中的 fileId 集合，避免同一个文件被重复排入刷新队列
+	refreshing sync.Map
+}
+
+// refreshJob 一次后台刷新任务所需的定位信息
+type refreshJob struct {
+	fileId    string
+	namespace string
+	group     string
+	fileName  string
+}
+
+// RefreshConfig 控制 refresh-after-write 式的后台异步刷新，Workers <= 0 表示不开启
+type RefreshConfig struct {
+	// Window 命中的 Entry 距离过期时间不足 Window 时，触发一次后台异步刷新
+	Window time.Duration
+	// Workers 后台刷新 worker pool 大小
+	Workers int
+}
+
+// freqNode 频次链表上的一个节点，持有所有访问次数等于 freq 的缓存条目。items 用 container/list
+// 维护该频次内的访问顺序（PushBack 到队尾即为最近访问），淘汰时从队首取出即为该频次下最久未访问的
+// 条目，从而实现类型注释里承诺的“频次相同时按 LRU 淘汰”。lfuNode.elem 记录了各自在 items 里的
+// 位置，移除时不需要再按 fileId 查找
+type freqNode struct {
+	freq  int
+	items *list.List
+	prev  *freqNode
+	next  *freqNode
+}
+
+func newFreqNode(freq int) *freqNode {
+	return &freqNode{freq: freq, items: list.New()}
+}
+
+// lfuNode 缓存条目在 LFU 结构中的包装，记录其归属的频次节点以及在该频次 items 链表中的位置。
+// 命中统计（hitCount/lastAccessTime）也维护在这里而不是 Entry 上：lfuNode 的读写全部在 fc.mu
+// 保护下进行，而 *Entry 会被 Get/GetOrLoadIfAbsent 直接返回给调用方长期持有，挂在 Entry 上会
+// 在无锁读者和后续 Get 之间产生数据竞争
+type lfuNode struct {
+	fileId         string
+	entry          *Entry
+	parent         *freqNode
+	elem           *list.Element
+	hitCount       uint64
+	lastAccessTime time.Time
+}
+
+// NewLocalFileCache storage 用于 loading 缺失的缓存，maxEntries/maxByteSize 用于控制 LFU 淘汰策略，
+// 任意一个 <= 0 表示对应维度不限制。diskConf.Dir 非空时开启磁盘二级缓存，重启时会先从磁盘目录
+// 恢复未过期的条目，避免冷启动对 MySQL 造成惊群式打穿。refreshConf.Workers > 0 时开启
+// refresh-after-write：临近过期的 Entry 会在命中时触发一次后台异步刷新，而不是等下一次请求
+// 同步阻塞重新加载
+func NewLocalFileCache(storage store.Store, maxEntries int, maxByteSize int64, diskConf DiskConfig,
+	refreshConf RefreshConfig) *LocalFileCache {
+	cache := &LocalFileCache{
+		storage:       storage,
+		maxEntries:    maxEntries,
+		maxByteSize:   maxByteSize,
+		files:         make(map[string]*lfuNode),
+		refreshWindow: refreshConf.Window,
+	}
+
+	if diskConf.Dir != "" {
+		disk, err := newDiskTier(diskConf)
+		if err != nil {
+			log.GetConfigLogger().Error("[Config][Cache] init disk tier error.", zap.Error(err))
+		} else {
+			cache.disk = disk
+			cache.rehydrateFromDisk()
+			cache.disk.startCompactionTask()
+		}
+	}
+
+	if refreshConf.Workers > 0 {
+		cache.refreshJobs = make(chan refreshJob, refreshConf.Workers)
+		cache.startRefreshWorkers(refreshConf.Workers)
+	}
+
+	cache.startClearExpireEntryTask()
+	cache.startReportMetricsTask()
+
+	return cache
+}
+
+// rehydrateFromDisk 在启动时扫描磁盘目录，将未过期的条目加载回内存，避免重启后缓存全部落空
+func (fc *LocalFileCache) rehydrateFromDisk() {
+	records, err := fc.disk.loadAll()
+	if err != nil {
+		log.GetConfigLogger().Error("[Config][Cache] rehydrate disk cache error.", zap.Error(err))
+		return
+	}
+
+	for _, record := range records {
+		fc.put(record.FileId, record.Entry)
+	}
+
+	if len(records) > 0 {
+		log.GetConfigLogger().Info("[Config][Cache] rehydrated file cache from disk.", zap.Int("count", len(records)))
+	}
+}
+
+// Put 写入缓存对象
+func (fc *LocalFileCache) Put(file *model.ConfigFileRelease) {
+	fileCachePutTotal.WithLabelValues(file.Namespace).Inc()
+	fileId := GenFileId(file.Namespace, file.Group, file.FileName)
+
+	//幂等判断，只能存入版本号更大的。这里用 peek 而不是 Get/get，因为 Put 是写路径，不应该
+	//像一次真实的读命中那样推高 LFU 频次，否则写多读少的文件会在淘汰时显得比真正的热点更“热”
+	storedEntry, ok := fc.peek(fileId)
+	if !ok || storedEntry.Empty || file.Version > storedEntry.Version {
+		entry := newEntry(file.Content, file.Md5, file.Version)
+		fc.put(fileId, entry)
+		fc.writeThroughDisk(fileId, entry)
+	}
+}
+
+// Get 一般用于内部服务调用，所以不计入 metrics
+func (fc *LocalFileCache) Get(namespace, group, fileName string) (*Entry, bool) {
+	fileId := GenFileId(namespace, group, fileName)
+	return fc.get(fileId)
+}
+
+// peek 读取一个条目但不触碰其 LFU 频次，用于写路径上的幂等判断等不应被计为一次命中的场景
+func (fc *LocalFileCache) peek(fileId string) (*Entry, bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	node, ok := fc.files[fileId]
+	if !ok {
+		return nil, false
+	}
+	return node.entry, true
+}
+
+// GetOrLoadIfAbsent 获取缓存，如果缓存没命中则会从数据库中加载，如果数据库里获取不到数据，则会缓存一个空对象防止缓存一直被击穿。
+// 并发请求同一个 fileId 时通过 singleflight 合并为一次加载，大家共享同一份结果/错误
+func (fc *LocalFileCache) GetOrLoadIfAbsent(namespace, group, fileName string) (*Entry, error) {
+	fileCacheGetTotal.WithLabelValues(namespace).Inc()
+
+	fileId := GenFileId(namespace, group, fileName)
+	if entry, ok := fc.get(fileId); ok {
+		fc.maybeScheduleRefresh(fileId, namespace, group, fileName, entry)
+		return entry, nil
+	}
+
+	result, err, _ := fc.loadGroup.Do(fileId, func() (interface{}, error) {
+		//double check，可能在排队等待 singleflight 执行期间已经被其他请求加载完成
+		if entry, ok := fc.get(fileId); ok {
+			return entry, nil
+		}
+
+		//内存未命中时，先查磁盘二级缓存，命中则直接回种内存，避免穿透到数据库
+		if fc.disk != nil {
+			if entry, ok := fc.disk.get(fileId); ok {
+				fc.put(fileId, entry)
+				return entry, nil
+			}
+		}
+
+		fileCacheLoadTotal.WithLabelValues(namespace).Inc()
+
+		file, err := fc.storage.GetConfigFileRelease(nil, namespace, group, fileName)
+		if err != nil {
+			log.GetConfigLogger().Error("[Config][Cache] load config file release error.",
+				zap.String("namespace", namespace),
+				zap.String("group", group),
+				zap.String("fileName", fileName),
+				zap.Error(err))
+			return nil, err
+		}
+
+		if file != nil {
+			entry := newEntry(file.Content, file.Md5, file.Version)
+			fc.put(fileId, entry)
+			fc.writeThroughDisk(fileId, entry)
+			return entry, nil
+		}
+
+		//为了避免对象不存在时，一直击穿数据库，所以缓存空对象
+		emptyEntry := &Entry{
+			Content:    "",
+			ExpireTime: getExpireTime(),
+			Empty:      true,
+		}
+		fc.put(fileId, emptyEntry)
+
+		return emptyEntry, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(*Entry), nil
+}
+
+// maybeScheduleRefresh 当命中的 Entry 即将过期时，异步触发一次刷新，避免等到下一次请求才
+// 同步阻塞加载；同一个 fileId 同时只会有一个刷新任务在途
+func (fc *LocalFileCache) maybeScheduleRefresh(fileId, namespace, group, fileName string, entry *Entry) {
+	if fc.refreshJobs == nil || fc.refreshWindow <= 0 || entry.Empty {
+		return
+	}
+	if time.Until(entry.ExpireTime) > fc.refreshWindow {
+		return
+	}
+	if _, loaded := fc.refreshing.LoadOrStore(fileId, struct{}{}); loaded {
+		return
+	}
+
+	select {
+	case fc.refreshJobs <- refreshJob{fileId: fileId, namespace: namespace, group: group, fileName: fileName}:
+	default:
+		//worker pool 已经饱和，放弃本次刷新，等待下一次命中再尝试
+		fc.refreshing.Delete(fileId)
+	}
+}
+
+// startRefreshWorkers 启动固定数量的 worker 消费 refreshJobs，使后台刷新的并发度有界
+func (fc *LocalFileCache) startRefreshWorkers(n int) {
+	for i := 0; i < n; i++ {
+		go func() {
+			for job := range fc.refreshJobs {
+				fc.reload(job)
+				fc.refreshing.Delete(job.fileId)
+			}
+		}()
+	}
+}
+
+// reload 复用 singleflight 从存储层重新加载一个文件，与前台请求的加载共享同一个 in-flight 调用
+func (fc *LocalFileCache) reload(job refreshJob) {
+	_, err, _ := fc.loadGroup.Do(job.fileId, func() (interface{}, error) {
+		file, err := fc.storage.GetConfigFileRelease(nil, job.namespace, job.group, job.fileName)
+		if err != nil {
+			return nil, err
+		}
+		if file == nil {
+			//与 GetOrLoadIfAbsent 一致缓存空对象，加入同一个 in-flight 调用的前台请求也能拿到 *Entry
+			emptyEntry := &Entry{
+				Content:    "",
+				ExpireTime: getExpireTime(),
+				Empty:      true,
+			}
+			fc.put(job.fileId, emptyEntry)
+			return emptyEntry, nil
+		}
+
+		entry := newEntry(file.Content, file.Md5, file.Version)
+		fc.put(job.fileId, entry)
+		fc.writeThroughDisk(job.fileId, entry)
+		return entry, nil
+	})
+	if err != nil {
+		log.GetConfigLogger().Error("[Config][Cache] background refresh config file release error.",
+			zap.String("namespace", job.namespace),
+			zap.String("group", job.group),
+			zap.String("fileName", job.fileName),
+			zap.Error(err))
+	}
+}
+
+// Remove 删除缓存对象
+func (fc *LocalFileCache) Remove(namespace, group, fileName string) {
+	fileCacheRemoveTotal.WithLabelValues(namespace).Inc()
+	fileId := GenFileId(namespace, group, fileName)
+
+	fc.mu.Lock()
+	fc.removeLocked(fileId)
+	fc.mu.Unlock()
+
+	if fc.disk != nil {
+		fc.disk.remove(fileId)
+	}
+}
+
+// writeThroughDisk 异步将条目落盘到磁盘二级缓存，不阻塞当前写入路径
+func (fc *LocalFileCache) writeThroughDisk(fileId string, entry *Entry) {
+	if fc.disk == nil {
+		return
+	}
+	go fc.disk.put(fileId, entry)
+}
+
+// get 从 LFU 结构中读取条目，命中时将其访问频次 +1，并记录命中次数/最近访问时间供 Stats 使用
+func (fc *LocalFileCache) get(fileId string) (*Entry, bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	node, ok := fc.files[fileId]
+	if !ok {
+		return nil, false
+	}
+	node.hitCount++
+	node.lastAccessTime = time.Now()
+	fc.touchLocked(node)
+	return node.entry, true
+}
+
+// put 写入或覆盖一个缓存条目，新条目的初始访问频次为 1；超出容量限制时淘汰最低频次的条目。
+// 覆盖已有条目时不会推高其访问频次——写入不算一次命中，否则写多读少的文件会在 LFU 淘汰时
+// 显得比真正被频繁读取的条目更“热”
+func (fc *LocalFileCache) put(fileId string, entry *Entry) {
+	fc.mu.Lock()
+
+	if node, ok := fc.files[fileId]; ok {
+		fc.curByteSize += int64(len(entry.Content)) - int64(len(node.entry.Content))
+		node.entry = entry
+	} else {
+		node = &lfuNode{fileId: fileId, entry: entry}
+		fc.files[fileId] = node
+		fc.curByteSize += int64(len(entry.Content))
+		fc.insertAtFreqOneLocked(node)
+	}
+
+	evicted := fc.evictIfOverCapacityLocked()
+	fc.mu.Unlock()
+
+	fc.notifyEvicted(evicted)
+}
+
+// evictedEntry 一次 evictIfOverCapacityLocked 中被淘汰的条目，留到释放 fc.mu 之后再通知 OnEvicted
+type evictedEntry struct {
+	fileId string
+	entry  *Entry
+}
+
+// notifyEvicted 在 fc.mu 之外调用 OnEvicted，避免回调里重新调用 Get/Put/Remove/Stats
+// 等同样需要 fc.mu 的方法时，与非重入的 sync.Mutex 死锁
+func (fc *LocalFileCache) notifyEvicted(evicted []evictedEntry) {
+	if fc.OnEvicted == nil {
+		return
+	}
+	for _, e := range evicted {
+		fc.OnEvicted(e.fileId, e.entry)
+	}
+}
+
+// touchLocked 将 node 从当前频次节点移动到 freq+1 的频次节点，并把它放到目标频次 items 的队尾，
+// 使其成为该频次下最近访问的条目；调用方需持有 fc.mu
+func (fc *LocalFileCache) touchLocked(node *lfuNode) {
+	cur := node.parent
+	nextFreq := cur.freq + 1
+
+	fc.detachLocked(cur, node)
+
+	var next *freqNode
+	if cur.next != nil && cur.next.freq == nextFreq {
+		next = cur.next
+	} else {
+		next = newFreqNode(nextFreq)
+		fc.linkAfterLocked(cur, next)
+	}
+	fc.attachLocked(next, node)
+
+	if cur.items.Len() == 0 {
+		fc.unlinkLocked(cur)
+	}
+}
+
+// insertAtFreqOneLocked 将新节点插入频次为 1 的频次节点的队尾，调用方需持有 fc.mu
+func (fc *LocalFileCache) insertAtFreqOneLocked(node *lfuNode) {
+	if fc.freqHead == nil || fc.freqHead.freq != 1 {
+		head := newFreqNode(1)
+		head.next = fc.freqHead
+		if fc.freqHead != nil {
+			fc.freqHead.prev = head
+		}
+		fc.freqHead = head
+	}
+	fc.attachLocked(fc.freqHead, node)
+}
+
+// attachLocked 将 node 挂到 fn.items 的队尾（即该频次下最近访问的一端），调用方需持有 fc.mu
+func (fc *LocalFileCache) attachLocked(fn *freqNode, node *lfuNode) {
+	node.elem = fn.items.PushBack(node)
+	node.parent = fn
+}
+
+// detachLocked 将 node 从其所属频次节点的 items 中摘除，调用方需持有 fc.mu
+func (fc *LocalFileCache) detachLocked(fn *freqNode, node *lfuNode) {
+	fn.items.Remove(node.elem)
+	node.elem = nil
+}
+
+// linkAfterLocked 在 cur 之后插入 next 频次节点
+func (fc *LocalFileCache) linkAfterLocked(cur, next *freqNode) {
+	next.prev = cur
+	next.next = cur.next
+	if cur.next != nil {
+		cur.next.prev = next
+	}
+	cur.next = next
+}
+
+// unlinkLocked 从频次链表中摘除一个已经清空的频次节点
+func (fc *LocalFileCache) unlinkLocked(node *freqNode) {
+	if node.prev != nil {
+		node.prev.next = node.next
+	} else {
+		fc.freqHead = node.next
+	}
+	if node.next != nil {
+		node.next.prev = node.prev
+	}
+}
+
+// removeLocked 从 LFU 结构中移除一个条目，调用方需持有 fc.mu
+func (fc *LocalFileCache) removeLocked(fileId string) {
+	node, ok := fc.files[fileId]
+	if !ok {
+		return
+	}
+	delete(fc.files, fileId)
+	parent := node.parent
+	fc.detachLocked(parent, node)
+	fc.curByteSize -= int64(len(node.entry.Content))
+	if parent.items.Len() == 0 {
+		fc.unlinkLocked(parent)
+	}
+}
+
+// evictIfOverCapacityLocked 当超出 maxEntries 或 maxByteSize 时，持续淘汰最低频次节点中最久未
+// 访问的条目（items 队首），频次相同时即按 LRU 淘汰；调用方需持有 fc.mu。淘汰的条目只是收集下来
+// 返回，真正的 OnEvicted 回调留给调用方在释放 fc.mu 之后再触发，防止回调重入 fc.mu 造成死锁
+func (fc *LocalFileCache) evictIfOverCapacityLocked() []evictedEntry {
+	var evicted []evictedEntry
+	for fc.overCapacityLocked() && fc.freqHead != nil {
+		lowest := fc.freqHead
+		elem := lowest.items.Front()
+		if elem == nil {
+			break
+		}
+		node := elem.Value.(*lfuNode)
+		fc.removeLocked(node.fileId)
+		fileCacheEvictTotal.WithLabelValues(namespaceOfFileId(node.fileId)).Inc()
+		evicted = append(evicted, evictedEntry{fileId: node.fileId, entry: node.entry})
+	}
+	return evicted
+}
+
+func (fc *LocalFileCache) overCapacityLocked() bool {
+	if fc.maxEntries > 0 && len(fc.files) > fc.maxEntries {
+		return true
+	}
+	if fc.maxByteSize > 0 && fc.curByteSize > fc.maxByteSize {
+		return true
+	}
+	return false
+}
+
+//定时清理过期的缓存
+func (fc *LocalFileCache) startClearExpireEntryTask() {
+	t := time.NewTicker(time.Minute)
+	go func() {
+		for {
+			select {
+			case <-t.C:
+				curExpiredFileCnt := 0
+
+				fc.mu.Lock()
+				now := time.Now()
+				for fileId, node := range fc.files {
+					if now.After(node.entry.ExpireTime) {
+						fc.removeLocked(fileId)
+						fileCacheExpireTotal.WithLabelValues(namespaceOfFileId(fileId)).Inc()
+						curExpiredFileCnt++
+					}
+				}
+				fc.mu.Unlock()
+
+				if curExpiredFileCnt > 0 {
+					log.GetConfigLogger().Info("[Config][Cache] clear expired file cache.", zap.Int("count", curExpiredFileCnt))
+				}
+			}
+		}
+	}()
+}
+
+// startReportMetricsTask 定时将当前条目数/占用字节数上报到 Prometheus gauge，取代原先的
+// printf 风格的 cache status 日志，使其可以直接接入 Grafana
+func (fc *LocalFileCache) startReportMetricsTask() {
+	t := time.NewTicker(time.Minute)
+	go func() {
+		for range t.C {
+			fc.mu.Lock()
+			entries := len(fc.files)
+			bytes := fc.curByteSize
+			fc.mu.Unlock()
+
+			fileCacheEntries.Set(float64(entries))
+			fileCacheBytes.Set(float64(bytes))
+		}
+	}()
+}
+
+// FileStat 单个缓存条目的访问统计，供 Stats 返回热点文件
+type FileStat struct {
+	FileId         string
+	HitCount       uint64
+	LastAccessTime time.Time
+}
+
+// Stats 返回访问次数最高的 topN 个缓存条目，topN <= 0 时返回全部条目按命中次数降序排列
+func (fc *LocalFileCache) Stats(topN int) []FileStat {
+	fc.mu.Lock()
+	stats := make([]FileStat, 0, len(fc.files))
+	for fileId, node := range fc.files {
+		stats = append(stats, FileStat{
+			FileId:         fileId,
+			HitCount:       node.hitCount,
+			LastAccessTime: node.lastAccessTime,
+		})
+	}
+	fc.mu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].HitCount > stats[j].HitCount
+	})
+
+	if topN > 0 && topN < len(stats) {
+		stats = stats[:topN]
+	}
+	return stats
+}
@@ -0,0 +1,122 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"testing"
+
+	"github.com/polarismesh/polaris-server/common/model"
+)
+
+func putRelease(fc *LocalFileCache, namespace, group, fileName, content string, version uint64) {
+	fc.Put(&model.ConfigFileRelease{
+		Namespace: namespace,
+		Group:     group,
+		FileName:  fileName,
+		Content:   content,
+		Md5:       content,
+		Version:   version,
+	})
+}
+
+// TestLocalFileCache_EvictsLeastFrequentlyUsed 验证超出 maxEntries 时，淘汰的是访问频次最低的
+// 条目而不是最早写入或最近写入的条目
+func TestLocalFileCache_EvictsLeastFrequentlyUsed(t *testing.T) {
+	fc := NewLocalFileCache(nil, 2, DefaultMaxByteSize, DiskConfig{}, RefreshConfig{})
+
+	putRelease(fc, "ns", "group", "a", "a-v1", 1)
+	putRelease(fc, "ns", "group", "b", "b-v1", 1)
+
+	// 反复读取 a，使其频次显著高于 b
+	for i := 0; i < 3; i++ {
+		if _, ok := fc.Get("ns", "group", "a"); !ok {
+			t.Fatalf("expected a to be present before eviction")
+		}
+	}
+
+	// 再写入 c，触发超容量淘汰：b 的频次最低，应当被淘汰，a/c 应当保留
+	putRelease(fc, "ns", "group", "c", "c-v1", 1)
+
+	if _, ok := fc.Get("ns", "group", "b"); ok {
+		t.Fatalf("expected b to be evicted as the least frequently used entry")
+	}
+	if _, ok := fc.Get("ns", "group", "a"); !ok {
+		t.Fatalf("expected frequently read entry a to survive eviction")
+	}
+	if _, ok := fc.Get("ns", "group", "c"); !ok {
+		t.Fatalf("expected newly written entry c to survive eviction")
+	}
+}
+
+// TestLocalFileCache_PutDoesNotInflateFrequency 验证重复 Put 同一个 fileId（即便版本递增）不会
+// 像真实读命中那样推高其 LFU 频次，否则写多读少的文件会在淘汰时显得比真正的热点更“热”
+func TestLocalFileCache_PutDoesNotInflateFrequency(t *testing.T) {
+	fc := NewLocalFileCache(nil, 2, DefaultMaxByteSize, DiskConfig{}, RefreshConfig{})
+
+	// hot 只被 Put 反复覆盖，从不被读取
+	putRelease(fc, "ns", "group", "hot-write", "v1", 1)
+	for v := uint64(2); v <= 5; v++ {
+		putRelease(fc, "ns", "group", "hot-write", "v", v)
+	}
+
+	// hot-read 只被 Put 一次，随后反复读取
+	putRelease(fc, "ns", "group", "hot-read", "v1", 1)
+	for i := 0; i < 4; i++ {
+		if _, ok := fc.Get("ns", "group", "hot-read"); !ok {
+			t.Fatalf("expected hot-read to be present before eviction")
+		}
+	}
+
+	// 写入第三个条目触发淘汰：hot-write 只被 Put 过，频次应当仍是 1，必须先于被多次读取的 hot-read 被淘汰
+	putRelease(fc, "ns", "group", "cold", "v1", 1)
+
+	if _, ok := fc.Get("ns", "group", "hot-write"); ok {
+		t.Fatalf("expected write-only entry to be evicted before a frequently read entry")
+	}
+	if _, ok := fc.Get("ns", "group", "hot-read"); !ok {
+		t.Fatalf("expected frequently read entry to survive eviction over a write-only entry")
+	}
+}
+
+// TestLocalFileCache_TiesBrokenByLeastRecentlyUsed 验证同一频次内的淘汰顺序按最近最少使用（LRU）
+// 决定，而不是 Go map 遍历顺序随机决定的条目
+func TestLocalFileCache_TiesBrokenByLeastRecentlyUsed(t *testing.T) {
+	fc := NewLocalFileCache(nil, 2, DefaultMaxByteSize, DiskConfig{}, RefreshConfig{})
+
+	putRelease(fc, "ns", "group", "a", "a-v1", 1)
+	putRelease(fc, "ns", "group", "b", "b-v1", 1)
+
+	// 把 a 提到频次 2，此时频次 1 这一档只剩下 b
+	if _, ok := fc.Get("ns", "group", "a"); !ok {
+		t.Fatalf("expected a to be present before eviction")
+	}
+
+	// c 新写入，同样落在频次 1 这一档，与 b 同频次但比 b 更晚被访问
+	putRelease(fc, "ns", "group", "c", "c-v1", 1)
+
+	// 触发超容量淘汰时，b 和 c 频次相同，b 更久未被访问，应当优先于 c 被淘汰
+	if _, ok := fc.Get("ns", "group", "b"); ok {
+		t.Fatalf("expected b to be evicted as the least recently used entry within its frequency tier")
+	}
+	if _, ok := fc.Get("ns", "group", "a"); !ok {
+		t.Fatalf("expected a to survive eviction")
+	}
+	if _, ok := fc.Get("ns", "group", "c"); !ok {
+		t.Fatalf("expected c to survive eviction over the less recently touched b")
+	}
+}
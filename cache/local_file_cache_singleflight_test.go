@@ -0,0 +1,83 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/polarismesh/polaris-server/common/model"
+	"github.com/polarismesh/polaris-server/store"
+)
+
+// countingConfigFileStore 只关心 GetConfigFileRelease 被调用的次数，其余 store.Store 方法均通过
+// 内嵌的 nil 接口保留默认实现（不会被用到，真调用会 panic），避免为了这一个测试去实现整个大接口
+type countingConfigFileStore struct {
+	store.Store
+
+	calls int32
+	file  *model.ConfigFileRelease
+}
+
+func (s *countingConfigFileStore) GetConfigFileRelease(tx interface{}, namespace, group,
+	fileName string) (*model.ConfigFileRelease, error) {
+	atomic.AddInt32(&s.calls, 1)
+	// 模拟一次真实的数据库查询耗时，放大并发请求落在同一个加载窗口内的概率
+	time.Sleep(20 * time.Millisecond)
+	return s.file, nil
+}
+
+// TestLocalFileCache_GetOrLoadIfAbsentCoalescesConcurrentLoads 验证同一个 fileId 的并发
+// GetOrLoadIfAbsent 调用通过 singleflight 合并，只触发一次真正的存储层加载
+func TestLocalFileCache_GetOrLoadIfAbsentCoalescesConcurrentLoads(t *testing.T) {
+	fakeStorage := &countingConfigFileStore{
+		file: &model.ConfigFileRelease{
+			Namespace: "ns",
+			Group:     "group",
+			FileName:  "a",
+			Content:   "hello",
+			Md5:       "hello",
+			Version:   1,
+		},
+	}
+	fc := NewLocalFileCache(fakeStorage, DefaultMaxEntries, DefaultMaxByteSize, DiskConfig{}, RefreshConfig{})
+
+	const concurrency = 50
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			entry, err := fc.GetOrLoadIfAbsent("ns", "group", "a")
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if entry.Content != "hello" {
+				t.Errorf("unexpected content: %s", entry.Content)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&fakeStorage.calls); got != 1 {
+		t.Fatalf("expected exactly 1 storage load for %d concurrent callers, got %d", concurrency, got)
+	}
+}
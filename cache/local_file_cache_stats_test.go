@@ -0,0 +1,61 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import "testing"
+
+// TestLocalFileCache_StatsTopNSortAndTruncate 验证 Stats 按命中次数降序排列，并在 topN > 0 时
+// 截断到前 topN 个条目；topN <= 0 时返回全部条目
+func TestLocalFileCache_StatsTopNSortAndTruncate(t *testing.T) {
+	fc := NewLocalFileCache(nil, DefaultMaxEntries, DefaultMaxByteSize, DiskConfig{}, RefreshConfig{})
+
+	putRelease(fc, "ns", "group", "a", "a-v1", 1)
+	putRelease(fc, "ns", "group", "b", "b-v1", 1)
+	putRelease(fc, "ns", "group", "c", "c-v1", 1)
+
+	for i := 0; i < 3; i++ {
+		if _, ok := fc.Get("ns", "group", "a"); !ok {
+			t.Fatalf("expected a to be present")
+		}
+	}
+	if _, ok := fc.Get("ns", "group", "b"); !ok {
+		t.Fatalf("expected b to be present")
+	}
+
+	all := fc.Stats(0)
+	if len(all) != 3 {
+		t.Fatalf("expected 3 entries with topN<=0, got %d", len(all))
+	}
+	if all[0].FileId != GenFileId("ns", "group", "a") || all[0].HitCount != 3 {
+		t.Fatalf("expected a to be the top hit entry with 3 hits, got %+v", all[0])
+	}
+	if all[1].FileId != GenFileId("ns", "group", "b") || all[1].HitCount != 1 {
+		t.Fatalf("expected b to be the second hit entry with 1 hit, got %+v", all[1])
+	}
+	if all[2].HitCount != 0 {
+		t.Fatalf("expected c to have 0 hits, got %+v", all[2])
+	}
+
+	top2 := fc.Stats(2)
+	if len(top2) != 2 {
+		t.Fatalf("expected Stats(2) to truncate to 2 entries, got %d", len(top2))
+	}
+	if top2[0].FileId != GenFileId("ns", "group", "a") || top2[1].FileId != GenFileId("ns", "group", "b") {
+		t.Fatalf("expected the top 2 by hit count to be a and b, got %+v", top2)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/polarismesh/polaris-server/common/log"
+	"github.com/polarismesh/polaris-server/store"
+	"go.uber.org/zap"
+)
+
+// MemcachedConfig 连接 Memcached 缓存后端所需的配置
+type MemcachedConfig struct {
+	Addrs []string
+}
+
+// MemcachedFileCache ConfigFileCache 的 Memcached 实现，将 Entry 以 gob 编码存入 Memcached，并复用
+// Memcached 原生的 key 过期机制实现 60~70 分钟的随机 TTL。多个 polaris-server 副本共享同一个
+// Memcached 集群，可以避免各自独立预热缓存、各自独立打满存储层。Put/Get/GetOrLoadIfAbsent/Remove
+// 的公共流程由内嵌的 remoteFileCache 实现，这里只负责 Memcached 自身的读写
+type MemcachedFileCache struct {
+	*remoteFileCache
+	client *memcache.Client
+}
+
+// NewMemcachedFileCache storage 用于缓存未命中时加载数据，conf 用于连接 Memcached
+func NewMemcachedFileCache(storage store.Store, conf MemcachedConfig) (*MemcachedFileCache, error) {
+	client := memcache.New(conf.Addrs...)
+	if err := client.Ping(); err != nil {
+		return nil, err
+	}
+
+	fc := &MemcachedFileCache{client: client}
+	fc.remoteFileCache = newRemoteFileCache(storage, fc)
+	return fc, nil
+}
+
+func (fc *MemcachedFileCache) get(fileId string) (*Entry, bool) {
+	item, err := fc.client.Get(fileId)
+	if err != nil {
+		if err != memcache.ErrCacheMiss {
+			log.GetConfigLogger().Error("[Config][Cache] get memcached entry error.",
+				zap.String("fileId", fileId), zap.Error(err))
+		}
+		return nil, false
+	}
+
+	entry, err := decodeEntry(item.Value)
+	if err != nil {
+		log.GetConfigLogger().Error("[Config][Cache] decode memcached entry error.",
+			zap.String("fileId", fileId), zap.Error(err))
+		return nil, false
+	}
+	return entry, true
+}
+
+// set 以 gob 编码写入 Memcached，并复用 Memcached 原生过期机制承载 [60, 70] 分钟随机 TTL
+func (fc *MemcachedFileCache) set(fileId string, entry *Entry) {
+	data, err := encodeEntry(entry)
+	if err != nil {
+		log.GetConfigLogger().Error("[Config][Cache] encode memcached entry error.",
+			zap.String("fileId", fileId), zap.Error(err))
+		return
+	}
+
+	item := &memcache.Item{
+		Key:        fileId,
+		Value:      data,
+		Expiration: int32(getExpireDuration().Seconds()),
+	}
+	if err := fc.client.Set(item); err != nil {
+		log.GetConfigLogger().Error("[Config][Cache] put memcached entry error.",
+			zap.String("fileId", fileId), zap.Error(err))
+	}
+}
+
+func (fc *MemcachedFileCache) remove(fileId string) {
+	if err := fc.client.Delete(fileId); err != nil && err != memcache.ErrCacheMiss {
+		log.GetConfigLogger().Error("[Config][Cache] remove memcached entry error.",
+			zap.String("fileId", fileId), zap.Error(err))
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"strings"
+)
+
+// 以下 Prometheus 指标取代了原先的包级 putCnt/loadCnt/getCnt/removeCnt/expireCnt 计数器。
+// 原实现在并发 Put/Get 下自增没有做任何同步，存在数据竞争；改为 Prometheus 官方 client 提供的
+// 原子计数器后，同时按 namespace 打标签，可以直接在 Grafana 上按命名空间画图。
+var (
+	fileCachePutTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "polaris_config",
+		Subsystem: "file_cache",
+		Name:      "put_total",
+		Help:      "Total number of Put calls against the config file cache.",
+	}, []string{"namespace"})
+
+	fileCacheGetTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "polaris_config",
+		Subsystem: "file_cache",
+		Name:      "get_total",
+		Help:      "Total number of GetOrLoadIfAbsent calls against the config file cache.",
+	}, []string{"namespace"})
+
+	fileCacheLoadTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "polaris_config",
+		Subsystem: "file_cache",
+		Name:      "load_total",
+		Help:      "Total number of cache misses that fell through to storage.",
+	}, []string{"namespace"})
+
+	fileCacheRemoveTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "polaris_config",
+		Subsystem: "file_cache",
+		Name:      "remove_total",
+		Help:      "Total number of Remove calls against the config file cache.",
+	}, []string{"namespace"})
+
+	fileCacheExpireTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "polaris_config",
+		Subsystem: "file_cache",
+		Name:      "expire_total",
+		Help:      "Total number of entries removed by the periodic TTL sweep.",
+	}, []string{"namespace"})
+
+	fileCacheEvictTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "polaris_config",
+		Subsystem: "file_cache",
+		Name:      "evict_total",
+		Help:      "Total number of entries removed by LFU capacity eviction.",
+	}, []string{"namespace"})
+
+	fileCacheEntries = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "polaris_config",
+		Subsystem: "file_cache",
+		Name:      "entries",
+		Help:      "Current number of entries held in the local file cache.",
+	})
+
+	fileCacheBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "polaris_config",
+		Subsystem: "file_cache",
+		Name:      "bytes",
+		Help:      "Approximate total content bytes held in the local file cache.",
+	})
+)
+
+// namespaceOfFileId 从 fileId 中还原出 namespace，用于给指标打标签。fileId 由 GenFileId 拼接而成
+func namespaceOfFileId(fileId string) string {
+	idx := strings.Index(fileId, FileIdSeparator)
+	if idx < 0 {
+		return fileId
+	}
+	return fileId[:idx]
+}
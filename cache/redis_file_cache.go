@@ -0,0 +1,100 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/polarismesh/polaris-server/common/log"
+	"github.com/polarismesh/polaris-server/store"
+	"go.uber.org/zap"
+)
+
+// RedisConfig 连接 Redis 缓存后端所需的配置
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// RedisFileCache ConfigFileCache 的 Redis 实现，将 Entry 以 gob 编码存入 Redis，并复用 Redis
+// 原生的 key 过期机制实现 60~70 分钟的随机 TTL。多个 polaris-server 副本共享同一个 Redis，
+// 可以避免各自独立预热缓存、各自独立打满存储层。Put/Get/GetOrLoadIfAbsent/Remove 的公共流程
+// 由内嵌的 remoteFileCache 实现，这里只负责 Redis 自身的读写
+type RedisFileCache struct {
+	*remoteFileCache
+	client *redis.Client
+}
+
+// NewRedisFileCache storage 用于缓存未命中时加载数据，conf 用于连接 Redis
+func NewRedisFileCache(storage store.Store, conf RedisConfig) (*RedisFileCache, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     conf.Addr,
+		Password: conf.Password,
+		DB:       conf.DB,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, err
+	}
+
+	fc := &RedisFileCache{client: client}
+	fc.remoteFileCache = newRemoteFileCache(storage, fc)
+	return fc, nil
+}
+
+func (fc *RedisFileCache) get(fileId string) (*Entry, bool) {
+	data, err := fc.client.Get(context.Background(), fileId).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.GetConfigLogger().Error("[Config][Cache] get redis entry error.",
+				zap.String("fileId", fileId), zap.Error(err))
+		}
+		return nil, false
+	}
+
+	entry, err := decodeEntry(data)
+	if err != nil {
+		log.GetConfigLogger().Error("[Config][Cache] decode redis entry error.",
+			zap.String("fileId", fileId), zap.Error(err))
+		return nil, false
+	}
+	return entry, true
+}
+
+// set 以 gob 编码写入 Redis，并复用 Redis 原生过期机制承载 [60, 70] 分钟随机 TTL
+func (fc *RedisFileCache) set(fileId string, entry *Entry) {
+	data, err := encodeEntry(entry)
+	if err != nil {
+		log.GetConfigLogger().Error("[Config][Cache] encode redis entry error.",
+			zap.String("fileId", fileId), zap.Error(err))
+		return
+	}
+
+	if err := fc.client.Set(context.Background(), fileId, data, getExpireDuration()).Err(); err != nil {
+		log.GetConfigLogger().Error("[Config][Cache] put redis entry error.",
+			zap.String("fileId", fileId), zap.Error(err))
+	}
+}
+
+func (fc *RedisFileCache) remove(fileId string) {
+	if err := fc.client.Del(context.Background(), fileId).Err(); err != nil {
+		log.GetConfigLogger().Error("[Config][Cache] remove redis entry error.",
+			zap.String("fileId", fileId), zap.Error(err))
+	}
+}
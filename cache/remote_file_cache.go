@@ -0,0 +1,125 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"github.com/polarismesh/polaris-server/common/log"
+	"github.com/polarismesh/polaris-server/common/model"
+	"github.com/polarismesh/polaris-server/store"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// remoteBackend 屏蔽 Redis/Memcached 等远程缓存后端在读写单个条目上的差异，
+// remoteFileCache 基于它实现两者共用的 Put/Get/GetOrLoadIfAbsent/Remove 流程
+type remoteBackend interface {
+	get(fileId string) (*Entry, bool)
+	set(fileId string, entry *Entry)
+	remove(fileId string)
+}
+
+// remoteFileCache ConfigFileCache 在“数据实际存放于远程存储”这一类后端上的公共实现，
+// Redis/Memcached 只需要各自实现 remoteBackend 即可复用这里的加载/回填逻辑，
+// 避免像之前那样在两个文件里各写一份近乎相同的 Put/GetOrLoadIfAbsent
+type remoteFileCache struct {
+	storage   store.Store
+	backend   remoteBackend
+	loadGroup singleflight.Group
+}
+
+func newRemoteFileCache(storage store.Store, backend remoteBackend) *remoteFileCache {
+	return &remoteFileCache{storage: storage, backend: backend}
+}
+
+// Put 写入缓存对象
+func (fc *remoteFileCache) Put(file *model.ConfigFileRelease) {
+	fileCachePutTotal.WithLabelValues(file.Namespace).Inc()
+	fileId := GenFileId(file.Namespace, file.Group, file.FileName)
+
+	storedEntry, ok := fc.backend.get(fileId)
+	//幂等判断，只能存入版本号更大的
+	if !ok || storedEntry.Empty || file.Version > storedEntry.Version {
+		entry := newEntry(file.Content, file.Md5, file.Version)
+		fc.backend.set(fileId, entry)
+	}
+}
+
+// Get 一般用于内部服务调用，所以不计入 metrics
+func (fc *remoteFileCache) Get(namespace, group, fileName string) (*Entry, bool) {
+	fileId := GenFileId(namespace, group, fileName)
+	return fc.backend.get(fileId)
+}
+
+// GetOrLoadIfAbsent 获取缓存，如果缓存没命中则会从数据库中加载，如果数据库里获取不到数据，则会缓存一个空对象防止缓存一直被击穿。
+// 同一个 fileId 的并发加载通过 singleflight 合并为一次
+func (fc *remoteFileCache) GetOrLoadIfAbsent(namespace, group, fileName string) (*Entry, error) {
+	fileCacheGetTotal.WithLabelValues(namespace).Inc()
+
+	fileId := GenFileId(namespace, group, fileName)
+	if entry, ok := fc.backend.get(fileId); ok {
+		return entry, nil
+	}
+
+	result, err, _ := fc.loadGroup.Do(fileId, func() (interface{}, error) {
+		//double check，可能在排队等待 singleflight 执行期间已经被其他请求加载完成
+		if entry, ok := fc.backend.get(fileId); ok {
+			return entry, nil
+		}
+
+		fileCacheLoadTotal.WithLabelValues(namespace).Inc()
+
+		file, err := fc.storage.GetConfigFileRelease(nil, namespace, group, fileName)
+		if err != nil {
+			log.GetConfigLogger().Error("[Config][Cache] load config file release error.",
+				zap.String("namespace", namespace),
+				zap.String("group", group),
+				zap.String("fileName", fileName),
+				zap.Error(err))
+			return nil, err
+		}
+
+		if file != nil {
+			entry := newEntry(file.Content, file.Md5, file.Version)
+			fc.backend.set(fileId, entry)
+			return entry, nil
+		}
+
+		//为了避免对象不存在时，一直击穿数据库，所以缓存空对象。ExpireTime 与 local 后端保持一致，
+		//即便远程后端靠自身原生的 TTL 过期，也不应该让这个字段停留在零值
+		emptyEntry := &Entry{
+			Content:    "",
+			ExpireTime: getExpireTime(),
+			Empty:      true,
+		}
+		fc.backend.set(fileId, emptyEntry)
+
+		return emptyEntry, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(*Entry), nil
+}
+
+// Remove 删除缓存对象
+func (fc *remoteFileCache) Remove(namespace, group, fileName string) {
+	fileCacheRemoveTotal.WithLabelValues(namespace).Inc()
+	fileId := GenFileId(namespace, group, fileName)
+	fc.backend.remove(fileId)
+}
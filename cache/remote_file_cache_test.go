@@ -0,0 +1,138 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/polarismesh/polaris-server/common/model"
+)
+
+// fakeRemoteBackend 用一个加锁的内存 map 模拟 Redis/Memcached 的 get/set/remove，
+// 让 remoteFileCache 里与具体后端无关的公共逻辑（幂等版本判断、singleflight 合并加载、
+// 空对象回填）可以脱离真实的 Redis/Memcached 依赖单独测试
+type fakeRemoteBackend struct {
+	mu   sync.Mutex
+	data map[string]*Entry
+}
+
+func newFakeRemoteBackend() *fakeRemoteBackend {
+	return &fakeRemoteBackend{data: make(map[string]*Entry)}
+}
+
+func (b *fakeRemoteBackend) get(fileId string) (*Entry, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entry, ok := b.data[fileId]
+	return entry, ok
+}
+
+func (b *fakeRemoteBackend) set(fileId string, entry *Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data[fileId] = entry
+}
+
+func (b *fakeRemoteBackend) remove(fileId string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.data, fileId)
+}
+
+// TestRemoteFileCache_PutIsIdempotentByVersion 验证 Put 只会用版本号更大的内容覆盖已有条目，
+// RedisFileCache/MemcachedFileCache 均通过内嵌 remoteFileCache 复用这一行为
+func TestRemoteFileCache_PutIsIdempotentByVersion(t *testing.T) {
+	backend := newFakeRemoteBackend()
+	fc := newRemoteFileCache(nil, backend)
+
+	fc.Put(&model.ConfigFileRelease{Namespace: "ns", Group: "group", FileName: "a", Content: "v1", Md5: "v1", Version: 1})
+	fc.Put(&model.ConfigFileRelease{Namespace: "ns", Group: "group", FileName: "a", Content: "v0", Md5: "v0", Version: 0})
+
+	entry, ok := fc.Get("ns", "group", "a")
+	if !ok || entry.Content != "v1" {
+		t.Fatalf("expected the higher-version content to survive a lower-version Put, got %+v", entry)
+	}
+
+	fc.Put(&model.ConfigFileRelease{Namespace: "ns", Group: "group", FileName: "a", Content: "v2", Md5: "v2", Version: 2})
+
+	entry, ok = fc.Get("ns", "group", "a")
+	if !ok || entry.Content != "v2" {
+		t.Fatalf("expected a higher-version Put to overwrite the stored entry, got %+v", entry)
+	}
+}
+
+// TestRemoteFileCache_CachesEmptyEntryWithExpireTimeOnMiss 验证存储层查不到数据时缓存的空对象
+// 带有 ExpireTime，避免负缓存条目的 ExpireTime 停留在零值（历史上 Redis/Memcached 两个后端在
+// 提取出 remoteFileCache 之前，各自维护的空对象字面量都漏掉了这个字段）
+func TestRemoteFileCache_CachesEmptyEntryWithExpireTimeOnMiss(t *testing.T) {
+	backend := newFakeRemoteBackend()
+	fakeStorage := &countingConfigFileStore{file: nil}
+	fc := newRemoteFileCache(fakeStorage, backend)
+
+	entry, err := fc.GetOrLoadIfAbsent("ns", "group", "missing")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !entry.Empty {
+		t.Fatalf("expected an empty negative-cache entry, got %+v", entry)
+	}
+	if entry.ExpireTime.IsZero() {
+		t.Fatalf("expected the empty entry to carry a non-zero ExpireTime")
+	}
+}
+
+// TestRemoteFileCache_GetOrLoadIfAbsentCoalescesConcurrentLoads 验证同一个 fileId 的并发
+// GetOrLoadIfAbsent 调用通过 singleflight 合并，只触发一次真正的存储层加载
+func TestRemoteFileCache_GetOrLoadIfAbsentCoalescesConcurrentLoads(t *testing.T) {
+	backend := newFakeRemoteBackend()
+	fakeStorage := &countingConfigFileStore{
+		file: &model.ConfigFileRelease{
+			Namespace: "ns",
+			Group:     "group",
+			FileName:  "a",
+			Content:   "hello",
+			Md5:       "hello",
+			Version:   1,
+		},
+	}
+	fc := newRemoteFileCache(fakeStorage, backend)
+
+	const concurrency = 50
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			entry, err := fc.GetOrLoadIfAbsent("ns", "group", "a")
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if entry.Content != "hello" {
+				t.Errorf("unexpected content: %s", entry.Content)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&fakeStorage.calls); got != 1 {
+		t.Fatalf("expected exactly 1 storage load for %d concurrent callers, got %d", concurrency, got)
+	}
+}